@@ -0,0 +1,52 @@
+// Package chat sanitizes the short text payloads relayed over the chat and
+// emote channels so the frontend can render them without further escaping.
+package chat
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxMessageLength is the longest chat/emote payload the server will relay.
+const MaxMessageLength = 200
+
+// profaneWords is a small denylist of words to mask; it isn't meant to be
+// exhaustive, just enough to keep casual lobbies civil.
+var profaneWords = []string{
+	"fuck",
+	"shit",
+	"bitch",
+	"asshole",
+}
+
+// Sanitize truncates msg to MaxMessageLength, HTML-escapes it so it's safe
+// to render verbatim on the client, and masks denylisted words.
+func Sanitize(msg string) string {
+	if len(msg) > MaxMessageLength {
+		msg = truncateAtRuneBoundary(msg, MaxMessageLength)
+	}
+
+	msg = html.EscapeString(msg)
+
+	lower := strings.ToLower(msg)
+	for _, word := range profaneWords {
+		idx := strings.Index(lower, word)
+		for idx != -1 {
+			msg = msg[:idx] + strings.Repeat("*", len(word)) + msg[idx+len(word):]
+			lower = strings.ToLower(msg)
+			idx = strings.Index(lower, word)
+		}
+	}
+
+	return msg
+}
+
+// truncateAtRuneBoundary cuts s to at most limit bytes, backing off from
+// limit if that would land inside a multi-byte rune.
+func truncateAtRuneBoundary(s string, limit int) string {
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
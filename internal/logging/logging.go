@@ -0,0 +1,15 @@
+// Package logging configures the server's structured logger. Every log
+// site attaches relevant fields (room code, player ID, remote addr)
+// directly instead of interpolating them into a free-form message.
+package logging
+
+import (
+	"log/slog"
+	"os"
+)
+
+// New returns a JSON-handler slog.Logger writing to stdout.
+func New() *slog.Logger {
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})
+	return slog.New(handler)
+}
@@ -0,0 +1,44 @@
+// Package metrics defines the Prometheus collectors the server exposes on
+// /metrics so operators can alert on room-leak growth or abnormal
+// disconnect rates.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// ActiveRooms is the number of rooms currently held in the registry.
+	ActiveRooms = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "bingo_active_rooms",
+		Help: "Number of rooms currently tracked by the room manager.",
+	})
+
+	// ActiveConnections is the number of open WebSocket connections.
+	ActiveConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "bingo_active_connections",
+		Help: "Number of currently open WebSocket connections.",
+	})
+
+	// MessagesTotal counts messages processed per channel.
+	MessagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bingo_messages_total",
+		Help: "Number of messages processed, labeled by channel.",
+	}, []string{"channel"})
+
+	// JoinFailuresTotal counts join-room rejections, labeled by reason.
+	JoinFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bingo_join_failures_total",
+		Help: "Number of join-room attempts rejected, labeled by reason.",
+	}, []string{"reason"})
+
+	// GameDuration observes how long a room stayed open from creation to
+	// its removal from the registry (room exited or reaped as idle); a won
+	// game is not observed until its room is removed.
+	GameDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "bingo_game_duration_seconds",
+		Help:    "Duration of a game from room creation to its end.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
@@ -0,0 +1,75 @@
+// Package protocol defines the wire format between the server and clients:
+// a typed envelope carrying a channel name and a per-channel payload, plus
+// the request/response payload structs and validation for each channel.
+// This replaces decoding every incoming message straight into one
+// mostly-empty RoomResponse struct.
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Envelope is the outer shape of every message sent over the WebSocket.
+// Payload is decoded into a channel-specific struct once Channel is known.
+type Envelope struct {
+	Channel string          `json:"channel"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Error codes returned in an "error" channel frame.
+const (
+	CodeBadEnvelope = "bad-envelope"
+	CodeBadPayload  = "bad-payload"
+	CodeInvalid     = "invalid"
+	CodeNotFound    = "not-found"
+	CodeRoomFull    = "room-full"
+	CodeRateLimited = "rate-limited"
+)
+
+// Error is the payload of an "error" channel frame.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrValidation wraps a Code alongside the usual error message so handlers
+// can turn a failed Validate() call directly into an Error payload.
+type ErrValidation struct {
+	Code    string
+	Message string
+}
+
+func (e *ErrValidation) Error() string { return e.Message }
+
+func validationErr(code, message string) error {
+	return &ErrValidation{Code: code, Message: message}
+}
+
+// AsError converts any error into a wire Error payload, preserving the
+// Code from an ErrValidation and otherwise falling back to CodeBadPayload.
+func AsError(err error) Error {
+	var ve *ErrValidation
+	if errors.As(err, &ve) {
+		return Error{Code: ve.Code, Message: ve.Message}
+	}
+	return Error{Code: CodeBadPayload, Message: err.Error()}
+}
+
+// Decode parses raw bytes into an Envelope.
+func Decode(raw []byte) (Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return Envelope{}, err
+	}
+	return env, nil
+}
+
+// Encode wraps payload in an Envelope for channel.
+func Encode(channel string, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Envelope{Channel: channel, Payload: body})
+}
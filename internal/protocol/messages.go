@@ -0,0 +1,244 @@
+package protocol
+
+import "regexp"
+
+const (
+	minDimension = 3
+	maxDimension = 10
+	maxNameLen   = 40
+	maxChatLen   = 200
+)
+
+var roomCodePattern = regexp.MustCompile(`^[A-Za-z0-9]{3,10}$`)
+
+// ValidateRoomCode checks that code looks like something util.GenerateRoomCode
+// would have produced.
+func ValidateRoomCode(code string) error {
+	if !roomCodePattern.MatchString(code) {
+		return validationErr(CodeInvalid, "roomCode must be 3-10 alphanumeric characters")
+	}
+	return nil
+}
+
+// ValidateName checks a player display name.
+func ValidateName(name string) error {
+	if name == "" {
+		return validationErr(CodeInvalid, "name must not be empty")
+	}
+	if len(name) > maxNameLen {
+		return validationErr(CodeInvalid, "name is too long")
+	}
+	return nil
+}
+
+// ValidateDimension checks a board dimension is within playable bounds.
+func ValidateDimension(dimension int) error {
+	if dimension < minDimension || dimension > maxDimension {
+		return validationErr(CodeInvalid, "dimension is out of range")
+	}
+	return nil
+}
+
+// HelloReq is sent immediately after connecting so the server can check the
+// client's build against its own.
+type HelloReq struct {
+	AppVersion string `json:"appVersion"`
+}
+
+// HelloResp echoes the server's version back, or carries it on the
+// "force-refresh" channel when the client is stale.
+type HelloResp struct {
+	AppVersion string `json:"appVersion"`
+}
+
+// CreateRoomReq requests a new room.
+type CreateRoomReq struct {
+	Name       string `json:"name"`
+	Dimension  int    `json:"dimension"`
+	AppVersion string `json:"appVersion"`
+}
+
+func (r CreateRoomReq) Validate() error {
+	if err := ValidateName(r.Name); err != nil {
+		return err
+	}
+	return ValidateDimension(r.Dimension)
+}
+
+// CreateRoomResp is the reply to CreateRoomReq.
+type CreateRoomResp struct {
+	RoomCode string `json:"roomCode"`
+	PlayerID string `json:"playerId"`
+}
+
+// JoinRoomReq requests joining an existing room.
+type JoinRoomReq struct {
+	RoomCode   string `json:"roomCode"`
+	Name       string `json:"name"`
+	AppVersion string `json:"appVersion"`
+}
+
+func (r JoinRoomReq) Validate() error {
+	if err := ValidateRoomCode(r.RoomCode); err != nil {
+		return err
+	}
+	return ValidateName(r.Name)
+}
+
+// GameReadyResp is sent to both sides once a room has a creator and joiner.
+type GameReadyResp struct {
+	PeerName  string `json:"peerName"`
+	Dimension int    `json:"dimension,omitempty"`
+	IsCreator bool   `json:"isCreator"`
+	PlayerID  string `json:"playerId,omitempty"`
+}
+
+// RejoinReq resumes a session after a dropped connection.
+type RejoinReq struct {
+	RoomCode string `json:"roomCode"`
+	PlayerID string `json:"playerId"`
+}
+
+func (r RejoinReq) Validate() error {
+	if err := ValidateRoomCode(r.RoomCode); err != nil {
+		return err
+	}
+	if r.PlayerID == "" {
+		return validationErr(CodeInvalid, "playerId must not be empty")
+	}
+	return nil
+}
+
+// RejoinResp is sent back to the rejoining player with enough state to
+// resume the game, including both boards' marked cells since the server
+// is authoritative over them.
+type RejoinResp struct {
+	Dimension     int    `json:"dimension"`
+	Move          int    `json:"move"`
+	IsCreator     bool   `json:"isCreator"`
+	NextIsCreator bool   `json:"nextIsCreator"`
+	Marked        []bool `json:"marked"`
+	PeerMarked    []bool `json:"peerMarked"`
+}
+
+// PeerReconnectedResp notifies the other player that their opponent is back.
+type PeerReconnectedResp struct {
+	Dimension     int  `json:"dimension"`
+	Move          int  `json:"move"`
+	NextIsCreator bool `json:"nextIsCreator"`
+}
+
+// SpectateReq requests read-only access to an in-progress room.
+type SpectateReq struct {
+	RoomCode string `json:"roomCode"`
+}
+
+func (r SpectateReq) Validate() error {
+	return ValidateRoomCode(r.RoomCode)
+}
+
+// SpectateResp is the state snapshot sent to a newly joined spectator.
+type SpectateResp struct {
+	Dimension     int    `json:"dimension"`
+	Move          int    `json:"move"`
+	MoveHistory   []int  `json:"moveHistory,omitempty"`
+	MarkedCreator []bool `json:"markedCreator"`
+	MarkedJoiner  []bool `json:"markedJoiner"`
+	NextIsCreator bool   `json:"nextIsCreator"`
+}
+
+// RoomActionReq covers channels that only need a room code: win-claim,
+// retry, exit-room. Which side of the room the sender is comes from the
+// connection's authenticated identity, never the client.
+type RoomActionReq struct {
+	RoomCode string `json:"roomCode"`
+}
+
+func (r RoomActionReq) Validate() error {
+	return ValidateRoomCode(r.RoomCode)
+}
+
+// MoveReq carries a single board move. The cell it marks is only trusted
+// up to this shape check; the room manager is what actually validates it
+// against the current turn and board dimension. Which side of the room
+// the sender is comes from the connection's authenticated identity, never
+// the client.
+type MoveReq struct {
+	RoomCode string `json:"roomCode"`
+	Move     int    `json:"move"`
+}
+
+func (r MoveReq) Validate() error {
+	if err := ValidateRoomCode(r.RoomCode); err != nil {
+		return err
+	}
+	if r.Move < 0 {
+		return validationErr(CodeInvalid, "move must not be negative")
+	}
+	return nil
+}
+
+// MoveResp relays a move to the other side.
+type MoveResp struct {
+	Move int `json:"move"`
+}
+
+// GameOverResp announces the server-computed outcome of a move that
+// completed a winning line. Clients never claim their own win; the server
+// decides this the moment a move closes a row, column, or diagonal.
+type GameOverResp struct {
+	WinnerIsCreator bool `json:"winnerIsCreator"`
+}
+
+// ChatReq carries a short chat message. Which side of the room the sender
+// is comes from the connection's authenticated identity, never the client.
+type ChatReq struct {
+	RoomCode string `json:"roomCode"`
+	Message  string `json:"message"`
+}
+
+func (r ChatReq) Validate() error {
+	if err := ValidateRoomCode(r.RoomCode); err != nil {
+		return err
+	}
+	if r.Message == "" {
+		return validationErr(CodeInvalid, "message must not be empty")
+	}
+	if len(r.Message) > maxChatLen {
+		return validationErr(CodeInvalid, "message is too long")
+	}
+	return nil
+}
+
+// ChatResp is the relayed, sanitized chat message.
+type ChatResp struct {
+	From    string `json:"from"`
+	Message string `json:"message"`
+}
+
+// EmoteReq carries a short emote payload. Which side of the room the
+// sender is comes from the connection's authenticated identity, never the
+// client.
+type EmoteReq struct {
+	RoomCode string `json:"roomCode"`
+	Emote    string `json:"emote"`
+}
+
+func (r EmoteReq) Validate() error {
+	if err := ValidateRoomCode(r.RoomCode); err != nil {
+		return err
+	}
+	if r.Emote == "" {
+		return validationErr(CodeInvalid, "emote must not be empty")
+	}
+	if len(r.Emote) > maxChatLen {
+		return validationErr(CodeInvalid, "emote is too long")
+	}
+	return nil
+}
+
+// EmoteResp is the relayed emote.
+type EmoteResp struct {
+	From  string `json:"from"`
+	Emote string `json:"emote"`
+}
@@ -0,0 +1,42 @@
+package room
+
+// checkWin reports whether marked, a dimension x dimension board flattened
+// row-major, has a fully marked row, column, or diagonal.
+func checkWin(marked []bool, dimension int) bool {
+	for row := 0; row < dimension; row++ {
+		complete := true
+		for col := 0; col < dimension; col++ {
+			if !marked[row*dimension+col] {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			return true
+		}
+	}
+
+	for col := 0; col < dimension; col++ {
+		complete := true
+		for row := 0; row < dimension; row++ {
+			if !marked[row*dimension+col] {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			return true
+		}
+	}
+
+	diagonal, antiDiagonal := true, true
+	for i := 0; i < dimension; i++ {
+		if !marked[i*dimension+i] {
+			diagonal = false
+		}
+		if !marked[i*dimension+(dimension-1-i)] {
+			antiDiagonal = false
+		}
+	}
+	return diagonal || antiDiagonal
+}
@@ -0,0 +1,77 @@
+package room
+
+import "testing"
+
+func TestCheckWin(t *testing.T) {
+	tests := []struct {
+		name      string
+		dimension int
+		marked    []bool
+		want      bool
+	}{
+		{
+			name:      "empty board",
+			dimension: 3,
+			marked:    make([]bool, 9),
+			want:      false,
+		},
+		{
+			name:      "completed row",
+			dimension: 3,
+			marked: []bool{
+				true, true, true,
+				false, false, false,
+				false, false, false,
+			},
+			want: true,
+		},
+		{
+			name:      "completed column",
+			dimension: 3,
+			marked: []bool{
+				true, false, false,
+				true, false, false,
+				true, false, false,
+			},
+			want: true,
+		},
+		{
+			name:      "completed diagonal",
+			dimension: 3,
+			marked: []bool{
+				true, false, false,
+				false, true, false,
+				false, false, true,
+			},
+			want: true,
+		},
+		{
+			name:      "completed anti-diagonal",
+			dimension: 3,
+			marked: []bool{
+				false, false, true,
+				false, true, false,
+				true, false, false,
+			},
+			want: true,
+		},
+		{
+			name:      "scattered marks, no line",
+			dimension: 3,
+			marked: []bool{
+				true, true, false,
+				false, false, true,
+				false, true, false,
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkWin(tt.marked, tt.dimension); got != tt.want {
+				t.Errorf("checkWin(%v, %d) = %v, want %v", tt.marked, tt.dimension, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package room
+
+import "testing"
+
+// TestConcurrentJoinOnlyOneWins exercises Join from many goroutines racing
+// for the same room, guarding against a regression of the atomic
+// check-then-set under a single lock acquisition.
+func TestConcurrentJoinOnlyOneWins(t *testing.T) {
+	m := NewManager()
+	m.Create("CODE1", Player{Name: "creator", PlayerID: "creator"}, 3, "v1")
+
+	const attempts = 50
+	results := make(chan bool, attempts)
+	for i := 0; i < attempts; i++ {
+		i := i
+		go func() {
+			_, ok := m.Join("CODE1", Player{Name: "joiner", PlayerID: string(rune('a' + i))})
+			results <- ok
+		}()
+	}
+
+	wins := 0
+	for i := 0; i < attempts; i++ {
+		if <-results {
+			wins++
+		}
+	}
+	if wins != 1 {
+		t.Fatalf("got %d successful Join calls, want exactly 1", wins)
+	}
+}
+
+// TestConcurrentApplyMoveAndSpectateDontRace exercises the bug Snapshot
+// exists to prevent: ApplyMove mutating the board's backing arrays
+// concurrently with Spectate reading them. It's only meaningful run with
+// `go test -race`, but passes under a plain run too, since Spectate and
+// ApplyMove both hand back independent copies rather than live state.
+func TestConcurrentApplyMoveAndSpectateDontRace(t *testing.T) {
+	m, code, creatorID, joinerID := newTestRoom(t, 10)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		next := creatorID
+		for i := 0; i < 50; i++ {
+			_, _, _, ok := m.ApplyMove(code, next, i)
+			if !ok {
+				return
+			}
+			if next == creatorID {
+				next = joinerID
+			} else {
+				next = creatorID
+			}
+		}
+	}()
+
+	for i := 0; i < 50; i++ {
+		m.Spectate(code, nil)
+	}
+	<-done
+}
@@ -0,0 +1,434 @@
+// Package room owns the in-memory room registry. It replaces the old
+// package-level map in main with a Manager that guards every access behind
+// a mutex, since the server fields one goroutine per WebSocket and rooms are
+// read and written from any of them concurrently.
+package room
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"internal/metrics"
+
+	"github.com/gorilla/websocket"
+)
+
+// Player is one side of a Room.
+type Player struct {
+	Name      string
+	PlayerID  string
+	Socket    *websocket.Conn
+	Connected bool
+	LeftAt    time.Time
+}
+
+// Room is a single bingo match between a Creator and a Joiner, plus any
+// number of read-only Spectators watching along. The server is
+// authoritative over game state: MarkedCreator and MarkedJoiner hold each
+// side's own marked cells, and NextIsCreator/GameOver gate which moves are
+// legal, so a client can never fabricate a win or move out of turn.
+type Room struct {
+	Code          string
+	Creator       Player
+	Joiner        Player
+	Spectators    []*websocket.Conn
+	Dimension     int
+	AppVersion    string
+	LastMove      int
+	MoveHistory   []int
+	MarkedCreator []bool
+	MarkedJoiner  []bool
+	NextIsCreator bool
+	GameOver      bool
+	CreatedAt     time.Time
+}
+
+// Snapshot is an independent copy of the parts of a Room a caller needs
+// once it's done touching the registry. Manager methods return one of
+// these instead of a live *Room, so a caller reading it after the lock has
+// been released can never race with a concurrent mutation of the room
+// itself — every slice here has its own backing array.
+type Snapshot struct {
+	CreatorName   string
+	JoinerName    string
+	CreatorSocket *websocket.Conn
+	JoinerSocket  *websocket.Conn
+	Spectators    []*websocket.Conn
+	Dimension     int
+	LastMove      int
+	MoveHistory   []int
+	MarkedCreator []bool
+	MarkedJoiner  []bool
+	NextIsCreator bool
+}
+
+// snapshotOf copies out r's state. Callers must hold m.mu.
+func snapshotOf(r *Room) Snapshot {
+	return Snapshot{
+		CreatorName:   r.Creator.Name,
+		JoinerName:    r.Joiner.Name,
+		CreatorSocket: r.Creator.Socket,
+		JoinerSocket:  r.Joiner.Socket,
+		Spectators:    append([]*websocket.Conn(nil), r.Spectators...),
+		Dimension:     r.Dimension,
+		LastMove:      r.LastMove,
+		MoveHistory:   append([]int(nil), r.MoveHistory...),
+		MarkedCreator: append([]bool(nil), r.MarkedCreator...),
+		MarkedJoiner:  append([]bool(nil), r.MarkedJoiner...),
+		NextIsCreator: r.NextIsCreator,
+	}
+}
+
+// Manager is the concurrency-safe registry of active rooms.
+type Manager struct {
+	mu    sync.RWMutex
+	rooms map[string]*Room
+}
+
+// NewManager returns an empty room registry.
+func NewManager() *Manager {
+	return &Manager{rooms: make(map[string]*Room)}
+}
+
+// Create registers a new room with creator as its first player. The
+// creator always moves first.
+func (m *Manager) Create(code string, creator Player, dimension int, appVersion string) Snapshot {
+	creator.Connected = true
+	cells := dimension * dimension
+	r := &Room{
+		Code:          code,
+		Creator:       creator,
+		Dimension:     dimension,
+		AppVersion:    appVersion,
+		MarkedCreator: make([]bool, cells),
+		MarkedJoiner:  make([]bool, cells),
+		NextIsCreator: true,
+		CreatedAt:     time.Now(),
+	}
+
+	m.mu.Lock()
+	m.rooms[code] = r
+	snap := snapshotOf(r)
+	m.mu.Unlock()
+
+	metrics.ActiveRooms.Inc()
+	return snap
+}
+
+// Join attaches joiner to an existing room, atomically rejecting if the
+// room doesn't exist or already has a joiner. Checking and setting the
+// joiner under the same lock is what makes this safe against two
+// concurrent join-room requests for the same code — only one can win.
+func (m *Manager) Join(code string, joiner Player) (Snapshot, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, found := m.rooms[code]
+	if !found || r.Joiner.PlayerID != "" {
+		return Snapshot{}, false
+	}
+
+	joiner.Connected = true
+	r.Joiner = joiner
+	return snapshotOf(r), true
+}
+
+// Get returns the room for code, or ok=false if it doesn't exist. Callers
+// may only read fields that are fixed at creation (AppVersion, Dimension,
+// Code) — anything else on the returned *Room can change the moment the
+// lock is released.
+func (m *Manager) Get(code string) (*Room, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, ok := m.rooms[code]
+	return r, ok
+}
+
+// Remove drops a room from the registry and records its lifetime as a
+// completed game duration.
+func (m *Manager) Remove(code string) {
+	m.mu.Lock()
+	r, ok := m.rooms[code]
+	delete(m.rooms, code)
+	m.mu.Unlock()
+
+	if ok {
+		metrics.ActiveRooms.Dec()
+		metrics.GameDuration.Observe(time.Since(r.CreatedAt).Seconds())
+	}
+}
+
+// Rejoin swaps the stored socket for whichever side of the room matches
+// playerID, marking that side connected again. If a previous socket is on
+// record, it's closed: that connection's read loop will error out and run
+// its own deferred Disconnect, which Disconnect now recognizes as stale
+// (see below) and ignores. Without this, a slow-to-notice-it's-dead old
+// connection could fire Disconnect after the new one has already
+// reconnected, wrongly marking the player as gone. Rejoin reports
+// ok=false if the room or player doesn't exist.
+func (m *Manager) Rejoin(code string, playerID string, socket *websocket.Conn) (snap Snapshot, isCreator bool, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, found := m.rooms[code]
+	if !found {
+		return Snapshot{}, false, false
+	}
+
+	switch playerID {
+	case r.Creator.PlayerID:
+		closeStale(r.Creator.Socket, socket)
+		r.Creator.Socket = socket
+		r.Creator.Connected = true
+		return snapshotOf(r), true, true
+	case r.Joiner.PlayerID:
+		closeStale(r.Joiner.Socket, socket)
+		r.Joiner.Socket = socket
+		r.Joiner.Connected = true
+		return snapshotOf(r), false, true
+	default:
+		return Snapshot{}, false, false
+	}
+}
+
+// closeStale closes old if it's being replaced by a different connection.
+func closeStale(old, replacement *websocket.Conn) {
+	if old != nil && old != replacement {
+		old.Close()
+	}
+}
+
+// Authorize resolves which side of the room at code the connection
+// identified by playerID actually is. Callers use this instead of trusting
+// a client-asserted role, so a socket can only ever act as whichever
+// player it authenticated as on create-room/join-room/rejoin. ok is false
+// if playerID is empty, the room doesn't exist, or playerID doesn't match
+// either side of it.
+func (m *Manager) Authorize(code, playerID string) (snap Snapshot, isCreator bool, ok bool) {
+	if playerID == "" {
+		return Snapshot{}, false, false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r, found := m.rooms[code]
+	if !found {
+		return Snapshot{}, false, false
+	}
+
+	switch playerID {
+	case r.Creator.PlayerID:
+		return snapshotOf(r), true, true
+	case r.Joiner.PlayerID:
+		return snapshotOf(r), false, true
+	default:
+		return Snapshot{}, false, false
+	}
+}
+
+// ApplyMove resolves playerID's role in the room the same way Authorize
+// does, then validates and applies a move from that side. ok is false if
+// playerID isn't recognized, no joiner has connected yet, the game has
+// already ended, it isn't that player's turn, or the cell is out of range
+// or already marked — the caller should treat any of these as a rejected
+// move rather than trying to distinguish them. won reports whether this
+// move completed a winning line.
+func (m *Manager) ApplyMove(code, playerID string, move int) (snap Snapshot, isCreator bool, won bool, ok bool) {
+	if playerID == "" {
+		return Snapshot{}, false, false, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, found := m.rooms[code]
+	if !found || r.GameOver || r.Joiner.PlayerID == "" {
+		return Snapshot{}, false, false, false
+	}
+
+	switch playerID {
+	case r.Creator.PlayerID:
+		isCreator = true
+	case r.Joiner.PlayerID:
+		isCreator = false
+	default:
+		return Snapshot{}, false, false, false
+	}
+	if r.NextIsCreator != isCreator {
+		return Snapshot{}, false, false, false
+	}
+	if move < 0 || move >= r.Dimension*r.Dimension {
+		return Snapshot{}, false, false, false
+	}
+
+	marked := r.MarkedJoiner
+	if isCreator {
+		marked = r.MarkedCreator
+	}
+	if marked[move] {
+		return Snapshot{}, false, false, false
+	}
+	marked[move] = true
+
+	r.LastMove = move
+	r.MoveHistory = append(r.MoveHistory, move)
+	r.NextIsCreator = !r.NextIsCreator
+
+	if checkWin(marked, r.Dimension) {
+		r.GameOver = true
+		won = true
+	}
+	return snapshotOf(r), isCreator, won, true
+}
+
+// Reset resolves playerID's role the same way Authorize does, then clears
+// the room's board back to a fresh game for the same two players, for a
+// "retry" rematch.
+func (m *Manager) Reset(code, playerID string) (snap Snapshot, isCreator bool, ok bool) {
+	if playerID == "" {
+		return Snapshot{}, false, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, found := m.rooms[code]
+	if !found {
+		return Snapshot{}, false, false
+	}
+
+	switch playerID {
+	case r.Creator.PlayerID:
+		isCreator = true
+	case r.Joiner.PlayerID:
+		isCreator = false
+	default:
+		return Snapshot{}, false, false
+	}
+
+	cells := r.Dimension * r.Dimension
+	r.MarkedCreator = make([]bool, cells)
+	r.MarkedJoiner = make([]bool, cells)
+	r.NextIsCreator = true
+	r.GameOver = false
+	r.LastMove = 0
+	r.MoveHistory = nil
+	return snapshotOf(r), isCreator, true
+}
+
+// Spectate adds socket as a read-only viewer of the room and returns a
+// snapshot of its current state for the caller to send back. ok is false if
+// the room doesn't exist.
+func (m *Manager) Spectate(code string, socket *websocket.Conn) (snap Snapshot, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, found := m.rooms[code]
+	if !found {
+		return Snapshot{}, false
+	}
+
+	r.Spectators = append(r.Spectators, socket)
+	return snapshotOf(r), true
+}
+
+// RemoveSpectator drops socket from the room's spectator list once it
+// disconnects. Without this, broadcastToSpectators keeps writing to every
+// viewer that ever joined, including ones long gone.
+func (m *Manager) RemoveSpectator(code string, socket *websocket.Conn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, found := m.rooms[code]
+	if !found {
+		return
+	}
+
+	for i, s := range r.Spectators {
+		if s == socket {
+			r.Spectators = append(r.Spectators[:i], r.Spectators[i+1:]...)
+			return
+		}
+	}
+}
+
+// Disconnect marks the side of the room owned by playerID as no longer
+// connected and returns the other player so the caller can notify them.
+// socket must be the connection the caller was reading from; if it no
+// longer matches the one on record for playerID, a rejoin has already
+// replaced it, so this call is just the stale connection's own deferred
+// cleanup catching up after the fact and must be a no-op rather than
+// clobbering the player who already reconnected. ok is false if the room
+// or player couldn't be found, or the call was stale.
+func (m *Manager) Disconnect(code string, playerID string, socket *websocket.Conn) (peer Player, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, found := m.rooms[code]
+	if !found {
+		return Player{}, false
+	}
+
+	switch playerID {
+	case r.Creator.PlayerID:
+		if r.Creator.Socket != socket {
+			return Player{}, false
+		}
+		r.Creator.Connected = false
+		r.Creator.LeftAt = time.Now()
+		return r.Joiner, true
+	case r.Joiner.PlayerID:
+		if r.Joiner.Socket != socket {
+			return Player{}, false
+		}
+		r.Joiner.Connected = false
+		r.Joiner.LeftAt = time.Now()
+		return r.Creator, true
+	default:
+		return Player{}, false
+	}
+}
+
+// Reap evicts rooms whose creator and joiner have both been disconnected
+// for at least maxIdle, or whose creator has been disconnected that long
+// with no joiner ever arriving, returning the codes it removed.
+func (m *Manager) Reap(maxIdle time.Duration) []string {
+	cutoff := time.Now().Add(-maxIdle)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var evicted []string
+	for code, r := range m.rooms {
+		creatorStale := !r.Creator.Connected && r.Creator.LeftAt.Before(cutoff)
+		joinerIdle := r.Joiner.PlayerID == "" || (!r.Joiner.Connected && r.Joiner.LeftAt.Before(cutoff))
+		if creatorStale && joinerIdle {
+			delete(m.rooms, code)
+			metrics.ActiveRooms.Dec()
+			metrics.GameDuration.Observe(time.Since(r.CreatedAt).Seconds())
+			evicted = append(evicted, code)
+		}
+	}
+	return evicted
+}
+
+// StartJanitor runs Reap on a fixed interval until stop is closed.
+func (m *Manager) StartJanitor(interval, maxIdle time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if evicted := m.Reap(maxIdle); len(evicted) > 0 {
+					slog.Info("janitor reaped idle rooms", "rooms", evicted, "count", len(evicted))
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
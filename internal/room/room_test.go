@@ -0,0 +1,96 @@
+package room
+
+import "testing"
+
+func newTestRoom(t *testing.T, dimension int) (m *Manager, code, creatorID, joinerID string) {
+	t.Helper()
+	m = NewManager()
+	creatorID = "creator"
+	joinerID = "joiner"
+	m.Create("CODE1", Player{Name: "creator", PlayerID: creatorID}, dimension, "v1")
+	if _, ok := m.Join("CODE1", Player{Name: "joiner", PlayerID: joinerID}); !ok {
+		t.Fatalf("Join failed setting up test room")
+	}
+	return m, "CODE1", creatorID, joinerID
+}
+
+func TestApplyMoveRejectsOutOfTurn(t *testing.T) {
+	m, code, creatorID, joinerID := newTestRoom(t, 3)
+
+	if _, _, _, ok := m.ApplyMove(code, joinerID, 0); ok {
+		t.Fatalf("ApplyMove let joiner move before creator's first turn")
+	}
+	if _, _, _, ok := m.ApplyMove(code, creatorID, 0); !ok {
+		t.Fatalf("ApplyMove rejected creator's legitimate first move")
+	}
+	if _, _, _, ok := m.ApplyMove(code, creatorID, 1); ok {
+		t.Fatalf("ApplyMove let creator move twice in a row")
+	}
+}
+
+func TestApplyMoveRejectsAlreadyMarkedCell(t *testing.T) {
+	m, code, creatorID, joinerID := newTestRoom(t, 3)
+
+	if _, _, _, ok := m.ApplyMove(code, creatorID, 4); !ok {
+		t.Fatalf("ApplyMove rejected a legal first move")
+	}
+	if _, _, _, ok := m.ApplyMove(code, joinerID, 4); !ok {
+		t.Fatalf("ApplyMove rejected joiner's legal move")
+	}
+	if _, _, _, ok := m.ApplyMove(code, creatorID, 4); ok {
+		t.Fatalf("ApplyMove let creator re-mark a cell already in play")
+	}
+}
+
+func TestApplyMoveRejectsOutOfRangeCell(t *testing.T) {
+	m, code, creatorID, _ := newTestRoom(t, 3)
+
+	if _, _, _, ok := m.ApplyMove(code, creatorID, -1); ok {
+		t.Fatalf("ApplyMove accepted a negative cell")
+	}
+	if _, _, _, ok := m.ApplyMove(code, creatorID, 9); ok {
+		t.Fatalf("ApplyMove accepted a cell beyond the board")
+	}
+}
+
+func TestApplyMoveReportsWin(t *testing.T) {
+	m, code, creatorID, joinerID := newTestRoom(t, 3)
+
+	// Creator marks the top row: 0, 1, 2, interleaved with joiner's moves.
+	moves := []struct {
+		playerID string
+		cell     int
+	}{
+		{creatorID, 0},
+		{joinerID, 3},
+		{creatorID, 1},
+		{joinerID, 4},
+		{creatorID, 2},
+	}
+	for i, mv := range moves {
+		_, _, won, ok := m.ApplyMove(code, mv.playerID, mv.cell)
+		if !ok {
+			t.Fatalf("move %d: ApplyMove rejected a legal move", i)
+		}
+		wantWon := i == len(moves)-1
+		if won != wantWon {
+			t.Fatalf("move %d: won = %v, want %v", i, won, wantWon)
+		}
+	}
+
+	if _, _, _, ok := m.ApplyMove(code, joinerID, 5); ok {
+		t.Fatalf("ApplyMove accepted a move after the game was already won")
+	}
+}
+
+func TestJoinRejectsSecondJoiner(t *testing.T) {
+	m := NewManager()
+	m.Create("CODE1", Player{Name: "creator", PlayerID: "creator"}, 3, "v1")
+
+	if _, ok := m.Join("CODE1", Player{Name: "joiner1", PlayerID: "joiner1"}); !ok {
+		t.Fatalf("first Join was rejected")
+	}
+	if _, ok := m.Join("CODE1", Player{Name: "joiner2", PlayerID: "joiner2"}); ok {
+		t.Fatalf("second Join should have been rejected, room is already full")
+	}
+}
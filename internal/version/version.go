@@ -0,0 +1,24 @@
+// Package version holds the server build identity. Version is the value
+// clients must match during the hello handshake (join-room compares a
+// joiner's version with the room creator's, not with this one); Commit and
+// BuildTime are populated via -ldflags at release time and otherwise default
+// to "dev" and "unknown" so local builds keep working.
+package version
+
+var (
+	Version   = "dev"
+	Commit    = "dev"
+	BuildTime = "unknown"
+)
+
+// Info is the JSON shape returned by GET /version.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+}
+
+// Current returns the server's build identity.
+func Current() Info {
+	return Info{Version: Version, Commit: Commit, BuildTime: BuildTime}
+}
@@ -6,49 +6,39 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 	"util/util"
 
+	"internal/chat"
+	"internal/logging"
+	"internal/metrics"
+	"internal/protocol"
+	"internal/room"
+	"internal/version"
+
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/time/rate"
 )
 
-type RoomResponse struct {
-	Channel    string `json:"channel"`
-	Res        string `json:"res" default:""`
-	RoomCode   string `json:"roomCode" default:""`
-	Dimension  int    `json:"dimension" default:"0"`
-	IsCreator  bool   `json:"isCreator" default:"false"`
-	Move       int    `json:"move" default:"0"`
-	AppVersion string `json:"appVersion" default:""`
-}
+var logger = logging.New()
 
-type Player struct {
-	Name   string `default:""`
-	Socket *websocket.Conn
-}
-type Room struct {
-	Creator    Player
-	Joiner     Player
-	Dimension  int
-	AppVersion string
-}
+// chatRateLimit and chatRateBurst bound how often a single connection may
+// send chat/emote messages, independent of the unthrottled game channels.
+const (
+	chatRateLimit = 5
+	chatRateBurst = 10
+)
 
-var rooms = make(map[string]Room)
+// janitorInterval and roomIdleTimeout bound how long a room lingers after
+// both players have disconnected before it's reaped.
+const (
+	janitorInterval = 30 * time.Second
+	roomIdleTimeout = 10 * time.Minute
+)
 
-func createRoom(roomCode string, creator Player, dimension int, appVersion string) {
-	rooms[roomCode] = Room{Creator: creator, Dimension: dimension, AppVersion: appVersion}
-}
-func joinRoom(roomCode string, joiner Player) {
-	if entry, ok := rooms[roomCode]; ok {
-		entry.Joiner = joiner
-		rooms[roomCode] = entry
-	}
-}
-func getRoom(roomCode string) (Room, bool) {
-	if _, ok := rooms[roomCode]; ok {
-		return rooms[roomCode], false
-	}
-	return Room{}, true
-}
+var rooms = room.NewManager()
 
 var upgrader = websocket.Upgrader{}
 
@@ -68,93 +58,380 @@ func homePage(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Bingo Game Server - WebGL Compatible")
 }
 
+// broadcastToSpectators relays an envelope to every read-only viewer in
+// snap.
+func broadcastToSpectators(snap room.Snapshot, messageType int, msg []byte) {
+	for _, spectator := range snap.Spectators {
+		spectator.WriteMessage(messageType, msg)
+	}
+}
+
+// notifyPeer forwards msg to whichever side of snap the sender isn't, if
+// that side has actually connected yet.
+func notifyPeer(snap room.Snapshot, isCreator bool, messageType int, msg []byte) {
+	peer := snap.JoinerSocket
+	if !isCreator {
+		peer = snap.CreatorSocket
+	}
+	if peer != nil {
+		peer.WriteMessage(messageType, msg)
+	}
+}
+
+// send encodes payload as channel's envelope and writes it to conn,
+// logging (rather than silently dropping) any encoding failure.
+func send(conn *websocket.Conn, messageType int, channel string, payload interface{}) {
+	msg, err := protocol.Encode(channel, payload)
+	if err != nil {
+		logger.Error("failed to encode message", "channel", channel, "error", err)
+		return
+	}
+	conn.WriteMessage(messageType, msg)
+}
+
+// sendError writes a structured "error" frame for err.
+func sendError(conn *websocket.Conn, messageType int, err error) {
+	send(conn, messageType, "error", protocol.AsError(err))
+}
+
+// reader pumps messages off a single WebSocket until it errors out, at
+// which point it notifies the peer if the connection had joined a room.
 func reader(conn *websocket.Conn) {
+	var roomCode, playerID string
+	var spectating bool
+	chatLimiter := rate.NewLimiter(chatRateLimit, chatRateBurst)
+
+	defer func() {
+		if spectating {
+			rooms.RemoveSpectator(roomCode, conn)
+			return
+		}
+		if roomCode == "" || playerID == "" {
+			return
+		}
+		peer, ok := rooms.Disconnect(roomCode, playerID, conn)
+		if ok && peer.Socket != nil {
+			send(peer.Socket, websocket.TextMessage, "peer-left", struct{}{})
+		}
+	}()
+
 	for {
-		messageType, p, err := conn.ReadMessage()
+		messageType, raw, err := conn.ReadMessage()
 		if err != nil {
-			log.Println("WebSocket read error:", err)
+			logger.Info("websocket read error", "error", err, "roomCode", roomCode, "playerId", playerID)
 			return
 		}
-		var data RoomResponse
-		json.Unmarshal([]byte(p), &data)
 
-		switch data.Channel {
+		env, err := protocol.Decode(raw)
+		if err != nil {
+			logger.Warn("failed to decode envelope", "error", err, "remoteAddr", conn.RemoteAddr().String())
+			sendError(conn, messageType, &protocol.ErrValidation{Code: protocol.CodeBadEnvelope, Message: "malformed message envelope"})
+			continue
+		}
+		metrics.MessagesTotal.WithLabelValues(env.Channel).Inc()
+
+		switch env.Channel {
+		case "hello":
+			var req protocol.HelloReq
+			if err := json.Unmarshal(env.Payload, &req); err != nil {
+				logger.Warn("failed to decode hello payload", "error", err)
+				sendError(conn, messageType, err)
+				continue
+			}
+
+			if req.AppVersion != version.Version {
+				logger.Info("client version mismatch, forcing refresh", "clientVersion", req.AppVersion, "serverVersion", version.Version)
+				send(conn, messageType, "force-refresh", protocol.HelloResp{AppVersion: version.Version})
+			} else {
+				send(conn, messageType, "hello", protocol.HelloResp{AppVersion: version.Version})
+			}
+
 		case "create-room":
-			log.Println("Creating room...")
-			roomCode := util.GenerateRoomCode(5)
+			var req protocol.CreateRoomReq
+			if err := json.Unmarshal(env.Payload, &req); err != nil {
+				logger.Warn("failed to decode create-room payload", "error", err)
+				sendError(conn, messageType, err)
+				continue
+			}
+			if err := req.Validate(); err != nil {
+				sendError(conn, messageType, err)
+				continue
+			}
+
+			code := util.GenerateRoomCode(5)
+			playerID = uuid.NewString()
+			roomCode = code
 
-			createRoom(roomCode, Player{Name: data.Res, Socket: conn}, data.Dimension, data.AppVersion)
+			rooms.Create(code, room.Player{Name: req.Name, PlayerID: playerID, Socket: conn}, req.Dimension, req.AppVersion)
+			logger.Info("room created", "roomCode", roomCode, "playerId", playerID)
 
-			msg, _ := json.Marshal(&RoomResponse{Channel: "create-room", Res: roomCode, RoomCode: roomCode})
-			conn.WriteMessage(messageType, msg)
+			send(conn, messageType, "create-room", protocol.CreateRoomResp{RoomCode: code, PlayerID: playerID})
 
 		case "join-room":
-			log.Println("Joining room...")
-			room, error := getRoom(data.RoomCode)
-			if error {
-				msgToJoiner, _ := json.Marshal(&RoomResponse{Channel: "error", Res: "The room code you entered is invalid"})
-				conn.WriteMessage(messageType, msgToJoiner)
-			} else {
-				if room.AppVersion == data.AppVersion {
-					if room.Joiner.Name == "" {
-						joinRoom(data.RoomCode, Player{Name: data.Res, Socket: conn})
-
-						msgToJoiner, _ := json.Marshal(&RoomResponse{Channel: "game-ready", Res: room.Creator.Name, Dimension: room.Dimension, IsCreator: false})
-						conn.WriteMessage(messageType, msgToJoiner)
-
-						msgToCreator, _ := json.Marshal(&RoomResponse{Channel: "game-ready", Res: data.Res, IsCreator: true})
-						room.Creator.Socket.WriteMessage(messageType, msgToCreator)
-					} else {
-						msgToJoiner, _ := json.Marshal(&RoomResponse{Channel: "error", Res: "Room is already full"})
-						conn.WriteMessage(messageType, msgToJoiner)
-					}
-				} else {
-					msgToJoiner, _ := json.Marshal(&RoomResponse{Channel: "error", Res: "Room creator has a different version of Bingo. Please make sure both have the latest version."})
-					conn.WriteMessage(messageType, msgToJoiner)
+			var req protocol.JoinRoomReq
+			if err := json.Unmarshal(env.Payload, &req); err != nil {
+				logger.Warn("failed to decode join-room payload", "error", err)
+				sendError(conn, messageType, err)
+				continue
+			}
+			if err := req.Validate(); err != nil {
+				sendError(conn, messageType, err)
+				continue
+			}
+
+			r, found := rooms.Get(req.RoomCode)
+			switch {
+			case !found:
+				metrics.JoinFailuresTotal.WithLabelValues("bad-code").Inc()
+				sendError(conn, messageType, &protocol.ErrValidation{Code: protocol.CodeNotFound, Message: "The room code you entered is invalid"})
+			case r.AppVersion != req.AppVersion:
+				metrics.JoinFailuresTotal.WithLabelValues("version-mismatch").Inc()
+				sendError(conn, messageType, &protocol.ErrValidation{Code: protocol.CodeInvalid, Message: "Room creator has a different version of Bingo. Please make sure both have the latest version."})
+			default:
+				newPlayerID := uuid.NewString()
+				joined, ok := rooms.Join(req.RoomCode, room.Player{Name: req.Name, PlayerID: newPlayerID, Socket: conn})
+				if !ok {
+					metrics.JoinFailuresTotal.WithLabelValues("full").Inc()
+					sendError(conn, messageType, &protocol.ErrValidation{Code: protocol.CodeRoomFull, Message: "Room is already full"})
+					continue
 				}
+				playerID = newPlayerID
+				roomCode = req.RoomCode
+				logger.Info("room joined", "roomCode", roomCode, "playerId", playerID)
+
+				send(conn, messageType, "game-ready", protocol.GameReadyResp{PeerName: joined.CreatorName, Dimension: joined.Dimension, IsCreator: false, PlayerID: playerID})
+				send(joined.CreatorSocket, messageType, "game-ready", protocol.GameReadyResp{PeerName: req.Name, IsCreator: true})
 			}
 
-			log.Println("Game is ready")
+		case "rejoin":
+			var req protocol.RejoinReq
+			if err := json.Unmarshal(env.Payload, &req); err != nil {
+				logger.Warn("failed to decode rejoin payload", "error", err)
+				sendError(conn, messageType, err)
+				continue
+			}
+			if err := req.Validate(); err != nil {
+				sendError(conn, messageType, err)
+				continue
+			}
+
+			logger.Info("player rejoining", "roomCode", req.RoomCode, "playerId", req.PlayerID)
+			snap, isCreator, ok := rooms.Rejoin(req.RoomCode, req.PlayerID, conn)
+			if !ok {
+				sendError(conn, messageType, &protocol.ErrValidation{Code: protocol.CodeNotFound, Message: "Unable to rejoin: room or player not found"})
+				continue
+			}
+			roomCode = req.RoomCode
+			playerID = req.PlayerID
+
+			peerSocket := snap.JoinerSocket
+			ownMarked, peerMarked := snap.MarkedCreator, snap.MarkedJoiner
+			if !isCreator {
+				peerSocket = snap.CreatorSocket
+				ownMarked, peerMarked = snap.MarkedJoiner, snap.MarkedCreator
+			}
+
+			send(conn, messageType, "rejoin", protocol.RejoinResp{
+				Dimension:     snap.Dimension,
+				Move:          snap.LastMove,
+				IsCreator:     isCreator,
+				NextIsCreator: snap.NextIsCreator,
+				Marked:        ownMarked,
+				PeerMarked:    peerMarked,
+			})
+
+			if peerSocket != nil {
+				send(peerSocket, messageType, "peer-reconnected", protocol.PeerReconnectedResp{Dimension: snap.Dimension, Move: snap.LastMove, NextIsCreator: snap.NextIsCreator})
+			}
+
+		case "spectate":
+			var req protocol.SpectateReq
+			if err := json.Unmarshal(env.Payload, &req); err != nil {
+				logger.Warn("failed to decode spectate payload", "error", err)
+				sendError(conn, messageType, err)
+				continue
+			}
+			if err := req.Validate(); err != nil {
+				sendError(conn, messageType, err)
+				continue
+			}
+
+			logger.Info("spectator joining room", "roomCode", req.RoomCode)
+			snapshot, found := rooms.Spectate(req.RoomCode, conn)
+			if !found {
+				sendError(conn, messageType, &protocol.ErrValidation{Code: protocol.CodeNotFound, Message: "The room code you entered is invalid"})
+				continue
+			}
+			roomCode = req.RoomCode
+			spectating = true
+
+			send(conn, messageType, "spectate", protocol.SpectateResp{
+				Dimension:     snapshot.Dimension,
+				Move:          snapshot.LastMove,
+				MoveHistory:   snapshot.MoveHistory,
+				MarkedCreator: snapshot.MarkedCreator,
+				MarkedJoiner:  snapshot.MarkedJoiner,
+				NextIsCreator: snapshot.NextIsCreator,
+			})
 
 		case "game-on":
-			room, _ := getRoom(data.RoomCode)
-			msg, _ := json.Marshal(&RoomResponse{Channel: "game-on", Move: data.Move})
-			if data.IsCreator {
-				room.Creator.Socket.WriteMessage(messageType, msg)
-			} else {
-				room.Joiner.Socket.WriteMessage(messageType, msg)
+			var req protocol.MoveReq
+			if err := json.Unmarshal(env.Payload, &req); err != nil {
+				logger.Warn("failed to decode game-on payload", "error", err)
+				sendError(conn, messageType, err)
+				continue
+			}
+			if err := req.Validate(); err != nil {
+				sendError(conn, messageType, err)
+				continue
+			}
+
+			snap, isCreator, won, ok := rooms.ApplyMove(roomCode, playerID, req.Move)
+			if !ok {
+				sendError(conn, messageType, &protocol.ErrValidation{Code: protocol.CodeInvalid, Message: "move rejected: not your turn, cell already marked, or out of range"})
+				continue
+			}
+
+			msg, _ := protocol.Encode("game-on", protocol.MoveResp{Move: req.Move})
+			notifyPeer(snap, isCreator, messageType, msg)
+			broadcastToSpectators(snap, messageType, msg)
+
+			if won {
+				logger.Info("game won", "roomCode", roomCode, "winnerIsCreator", isCreator)
+				overMsg, _ := protocol.Encode("game-over", protocol.GameOverResp{WinnerIsCreator: isCreator})
+				if snap.CreatorSocket != nil {
+					snap.CreatorSocket.WriteMessage(messageType, overMsg)
+				}
+				if snap.JoinerSocket != nil {
+					snap.JoinerSocket.WriteMessage(messageType, overMsg)
+				}
+				broadcastToSpectators(snap, messageType, overMsg)
 			}
 
 		case "win-claim":
-			room, _ := getRoom(data.RoomCode)
-			msg, _ := json.Marshal(&RoomResponse{Channel: "win-claim"})
-			if data.IsCreator {
-				room.Creator.Socket.WriteMessage(messageType, msg)
-			} else {
-				room.Joiner.Socket.WriteMessage(messageType, msg)
+			var req protocol.RoomActionReq
+			if err := json.Unmarshal(env.Payload, &req); err != nil {
+				logger.Warn("failed to decode win-claim payload", "error", err)
+				sendError(conn, messageType, err)
+				continue
 			}
+			if err := req.Validate(); err != nil {
+				sendError(conn, messageType, err)
+				continue
+			}
+
+			// Wins are computed authoritatively in ApplyMove and announced
+			// on "game-over" the instant a move completes a line, so a
+			// client asserting its own win here has nothing to gain.
+			logger.Warn("rejected client-asserted win-claim", "roomCode", roomCode, "playerId", playerID)
+			sendError(conn, messageType, &protocol.ErrValidation{Code: protocol.CodeInvalid, Message: "wins are determined by the server and announced on game-over"})
 
 		case "retry":
-			room, _ := getRoom(data.RoomCode)
-			msg, _ := json.Marshal(&RoomResponse{Channel: "retry"})
-			if data.IsCreator {
-				room.Creator.Socket.WriteMessage(messageType, msg)
-			} else {
-				room.Joiner.Socket.WriteMessage(messageType, msg)
+			var req protocol.RoomActionReq
+			if err := json.Unmarshal(env.Payload, &req); err != nil {
+				logger.Warn("failed to decode retry payload", "error", err)
+				sendError(conn, messageType, err)
+				continue
+			}
+			if err := req.Validate(); err != nil {
+				sendError(conn, messageType, err)
+				continue
+			}
+
+			snap, isCreator, ok := rooms.Reset(roomCode, playerID)
+			if !ok {
+				continue
+			}
+
+			msg, _ := protocol.Encode("retry", struct{}{})
+			notifyPeer(snap, isCreator, messageType, msg)
+			broadcastToSpectators(snap, messageType, msg)
+
+		case "chat":
+			var req protocol.ChatReq
+			if err := json.Unmarshal(env.Payload, &req); err != nil {
+				logger.Warn("failed to decode chat payload", "error", err)
+				sendError(conn, messageType, err)
+				continue
+			}
+			if err := req.Validate(); err != nil {
+				sendError(conn, messageType, err)
+				continue
+			}
+			if !chatLimiter.Allow() {
+				logger.Warn("chat rate limit exceeded", "roomCode", req.RoomCode)
+				sendError(conn, messageType, &protocol.ErrValidation{Code: protocol.CodeRateLimited, Message: "Slow down, you're sending messages too fast"})
+				continue
+			}
+
+			snap, isCreator, ok := rooms.Authorize(roomCode, playerID)
+			if !ok {
+				continue
 			}
 
+			from := snap.JoinerName
+			if isCreator {
+				from = snap.CreatorName
+			}
+
+			msg, _ := protocol.Encode("chat", protocol.ChatResp{From: from, Message: chat.Sanitize(req.Message)})
+			notifyPeer(snap, isCreator, messageType, msg)
+			broadcastToSpectators(snap, messageType, msg)
+
+		case "emote":
+			var req protocol.EmoteReq
+			if err := json.Unmarshal(env.Payload, &req); err != nil {
+				logger.Warn("failed to decode emote payload", "error", err)
+				sendError(conn, messageType, err)
+				continue
+			}
+			if err := req.Validate(); err != nil {
+				sendError(conn, messageType, err)
+				continue
+			}
+			if !chatLimiter.Allow() {
+				logger.Warn("chat rate limit exceeded", "roomCode", req.RoomCode)
+				sendError(conn, messageType, &protocol.ErrValidation{Code: protocol.CodeRateLimited, Message: "Slow down, you're sending messages too fast"})
+				continue
+			}
+
+			snap, isCreator, ok := rooms.Authorize(roomCode, playerID)
+			if !ok {
+				continue
+			}
+
+			from := snap.JoinerName
+			if isCreator {
+				from = snap.CreatorName
+			}
+
+			msg, _ := protocol.Encode("emote", protocol.EmoteResp{From: from, Emote: chat.Sanitize(req.Emote)})
+			notifyPeer(snap, isCreator, messageType, msg)
+			broadcastToSpectators(snap, messageType, msg)
+
 		case "exit-room":
-			room, _ := getRoom(data.RoomCode)
-			delete(rooms, data.RoomCode)
-			msg, _ := json.Marshal(&RoomResponse{Channel: "exit-room"})
-			if data.IsCreator {
-				room.Creator.Socket.WriteMessage(messageType, msg)
-			} else {
-				room.Joiner.Socket.WriteMessage(messageType, msg)
+			var req protocol.RoomActionReq
+			if err := json.Unmarshal(env.Payload, &req); err != nil {
+				logger.Warn("failed to decode exit-room payload", "error", err)
+				sendError(conn, messageType, err)
+				continue
+			}
+			if err := req.Validate(); err != nil {
+				sendError(conn, messageType, err)
+				continue
 			}
 
+			snap, isCreator, ok := rooms.Authorize(roomCode, playerID)
+			if !ok {
+				continue
+			}
+			rooms.Remove(roomCode)
+
+			msg, _ := protocol.Encode("exit-room", struct{}{})
+			notifyPeer(snap, isCreator, messageType, msg)
+
 		default:
-			log.Println("Channel not implemented:", data.Channel)
+			logger.Warn("channel not implemented", "channel", env.Channel)
 		}
 	}
 }
@@ -178,18 +455,36 @@ func wsEndpoint(w http.ResponseWriter, r *http.Request) {
 
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Println("WebSocket upgrade failed:", err)
+		logger.Error("websocket upgrade failed", "error", err, "remoteAddr", r.RemoteAddr)
 		http.Error(w, "websocket upgrade failed", http.StatusBadRequest)
 		return
 	}
-	log.Println("Client Connected from:", r.RemoteAddr)
+	logger.Info("client connected", "remoteAddr", r.RemoteAddr)
+	metrics.ActiveConnections.Inc()
+	defer metrics.ActiveConnections.Dec()
 	defer ws.Close()
 	reader(ws)
 }
 
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(version.Current())
+}
+
 func setupRoutes() {
 	http.HandleFunc("/", homePage)
 	http.HandleFunc("/ws", wsEndpoint)
+	http.HandleFunc("/version", versionHandler)
+	http.Handle("/metrics", promhttp.Handler())
 	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
@@ -218,6 +513,8 @@ func main() {
 		WriteBufferSize: 1024,
 	}
 
+	rooms.StartJanitor(janitorInterval, roomIdleTimeout, make(chan struct{}))
+
 	setupRoutes()
 	port := os.Getenv("PORT")
 	if port == "" {